@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/skybloodd/MapBox/geocoder"
+)
+
+// batchResult — один рядок результату пакетного геокодування,
+// готовий до запису у вихідний CSV.
+type batchResult struct {
+	Address  string
+	Location *geocoder.LocationInfo
+	Err      error
+}
+
+// runBatch читає адреси з inputPath (по одній на рядок або з CSV-колонкою
+// "address"), геокодує їх одночасно через пул воркерів і записує
+// результат у outputPath. Якщо geojsonPath не порожній, додатково
+// зберігає geometry усіх успішно геокодованих адрес як GeoJSON
+// FeatureCollection.
+func runBatch(ctx context.Context, geo geocoder.Geocoder, inputPath, outputPath string, workers int, format, geojsonPath string) error {
+	if workers < 1 {
+		workers = 4
+	}
+
+	addresses, err := readAddresses(inputPath)
+	if err != nil {
+		return fmt.Errorf("помилка читання вхідного файлу: %v", err)
+	}
+
+	jobs := make(chan int)
+	results := make([]batchResult, len(addresses))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				address := addresses[idx]
+				location, geoErr := geo.Geocode(ctx, address)
+				results[idx] = batchResult{Address: address, Location: location, Err: geoErr}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range addresses {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	// Індекси, не значення, передаються у канал, щоб воркери писали
+	// безпосередньо у свій слот results без потреби у мʼютексі.
+	wg.Wait()
+
+	if err := writeBatchCSV(outputPath, results, format); err != nil {
+		return fmt.Errorf("помилка запису вихідного CSV: %v", err)
+	}
+
+	if geojsonPath != "" {
+		if err := writeBatchGeoJSON(geojsonPath, results); err != nil {
+			return fmt.Errorf("помилка запису GeoJSON: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// readAddresses читає список адрес із inputPath. Файли з розширенням
+// .csv розбираються через encoding/csv (шукаючи колонку "address", або
+// беручи перше поле, якщо її немає); будь-який інший файл читається як
+// звичайний текст по одній адресі на рядок, щоб адреси з комами
+// (наприклад "м. Київ, вул. Хрещатик") не розбивались на кілька полів.
+func readAddresses(path string) ([]string, error) {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return readAddressesCSV(path)
+	}
+	return readAddressesPlainText(path)
+}
+
+func readAddressesPlainText(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var addresses []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" {
+			addresses = append(addresses, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
+
+func readAddressesCSV(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("вхідний файл порожній")
+	}
+
+	addressCol := -1
+	for i, header := range records[0] {
+		if strings.EqualFold(strings.TrimSpace(header), "address") {
+			addressCol = i
+			break
+		}
+	}
+
+	var addresses []string
+	if addressCol >= 0 {
+		for _, row := range records[1:] {
+			if addressCol < len(row) && strings.TrimSpace(row[addressCol]) != "" {
+				addresses = append(addresses, strings.TrimSpace(row[addressCol]))
+			}
+		}
+		return addresses, nil
+	}
+
+	for _, row := range records {
+		if len(row) > 0 && strings.TrimSpace(row[0]) != "" {
+			addresses = append(addresses, strings.TrimSpace(row[0]))
+		}
+	}
+	return addresses, nil
+}
+
+func writeBatchCSV(path string, results []batchResult, format string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	if err := w.Write([]string{"address", "lat", "lon", "country", "region", "city", "place_name", "error"}); err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		if r.Err != nil {
+			if err := w.Write([]string{r.Address, "", "", "", "", "", "", r.Err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		lat := fmt.Sprintf("%.6f", r.Location.Latitude)
+		lon := fmt.Sprintf("%.6f", r.Location.Longitude)
+		if format == "dms" {
+			lat = toDMS(r.Location.Latitude, true)
+			lon = toDMS(r.Location.Longitude, false)
+		}
+
+		row := []string{r.Address, lat, lon, r.Location.Country, r.Location.Region, r.Location.City, r.Location.PlaceName, ""}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toDMS форматує десятковий градус у вигляді градуси/хвилини/секунди,
+// наприклад 35°42'20.3"N.
+func toDMS(decimal float64, isLat bool) string {
+	hemisphere := "N"
+	if isLat {
+		if decimal < 0 {
+			hemisphere = "S"
+		}
+	} else {
+		hemisphere = "E"
+		if decimal < 0 {
+			hemisphere = "W"
+		}
+	}
+
+	abs := math.Abs(decimal)
+	degrees := math.Floor(abs)
+	minutesFull := (abs - degrees) * 60
+	minutes := math.Floor(minutesFull)
+	seconds := (minutesFull - minutes) * 60
+
+	return fmt.Sprintf("%d°%d'%.1f\"%s", int(degrees), int(minutes), seconds, hemisphere)
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   geoJSONPointGeometry   `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONPointGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func writeBatchGeoJSON(path string, results []batchResult) error {
+	fc := geoJSONFeatureCollection{Type: "FeatureCollection"}
+
+	for _, r := range results {
+		if r.Err != nil || r.Location == nil {
+			continue
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONPointGeometry{
+				Type:        "Point",
+				Coordinates: []float64{r.Location.Longitude, r.Location.Latitude},
+			},
+			Properties: map[string]interface{}{
+				"address":    r.Address,
+				"place_name": r.Location.PlaceName,
+				"country":    r.Location.Country,
+				"region":     r.Location.Region,
+				"city":       r.Location.City,
+			},
+		})
+	}
+
+	data, err := json.MarshalIndent(fc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/skybloodd/MapBox/geocoder"
+)
+
+// CacheConfig описує налаштування дискового кешу геокодування у
+// config.json: шлях до файлу, час життя запису (TTL) та максимальну
+// кількість записів, понад яку найстаріші витісняються.
+type CacheConfig struct {
+	Path       string `json:"path"`
+	TTLSeconds int    `json:"ttl_seconds"`
+	MaxEntries int    `json:"max_entries"`
+}
+
+func loadCacheConfig() CacheConfig {
+	data, _ := os.ReadFile("config.json")
+
+	var raw struct {
+		Cache *CacheConfig `json:"cache"`
+	}
+	json.Unmarshal(data, &raw)
+
+	cfg := CacheConfig{Path: "geocode_cache.json", TTLSeconds: 86400, MaxEntries: 10000}
+	if raw.Cache != nil {
+		if raw.Cache.Path != "" {
+			cfg.Path = raw.Cache.Path
+		}
+		if raw.Cache.TTLSeconds > 0 {
+			cfg.TTLSeconds = raw.Cache.TTLSeconds
+		}
+		if raw.Cache.MaxEntries > 0 {
+			cfg.MaxEntries = raw.Cache.MaxEntries
+		}
+	}
+	return cfg
+}
+
+type cacheRecord struct {
+	Value     json.RawMessage `json:"value"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// GeoCache — дисковий кеш результатів геокодування/маршрутів з TTL,
+// що зберігається одним JSON-файлом (шлях задається у config.json);
+// вбудована база даних (bbolt, SQLite тощо) не використовується.
+// Set не пише на диск синхронно — він лише виставляє прапорець dirty,
+// а фоновий воркер (flushLoop) періодично скидає весь кеш на диск
+// одним файлом, тож конкурентні воркери пакетного режиму не
+// серіалізуються на перезаписі файлу при кожному geocoder.Geocode.
+// Значення зберігаються як json.RawMessage, тому кеш придатний як для
+// LocationInfo (геокодування, зворотне геокодування), так і для
+// RouteData (маршрути) під одними й тими ж ключами.
+type GeoCache struct {
+	mu      sync.Mutex
+	path    string
+	ttl     time.Duration
+	maxSize int
+	entries map[string]cacheRecord
+	dirty   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func openGeoCache(cfg CacheConfig) *GeoCache {
+	c := &GeoCache{
+		path:    cfg.Path,
+		ttl:     time.Duration(cfg.TTLSeconds) * time.Second,
+		maxSize: cfg.MaxEntries,
+		entries: map[string]cacheRecord{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	c.load()
+	go c.flushLoop()
+	return c
+}
+
+func (c *GeoCache) load() {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+	json.Unmarshal(data, &c.entries)
+}
+
+// flushLoop скидає кеш на диск не частіше ніж раз на 200мс, поки є
+// незбережені зміни (dirty), замість переписування файлу при кожному Set.
+func (c *GeoCache) flushLoop() {
+	defer close(c.done)
+
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flushIfDirty()
+		case <-c.stop:
+			c.flushIfDirty()
+			return
+		}
+	}
+}
+
+func (c *GeoCache) flushIfDirty() {
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return
+	}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err != nil {
+		return
+	}
+	os.WriteFile(c.path, data, 0644)
+}
+
+// Close зупиняє фоновий воркер і синхронно записує останній стан кешу
+// на диск. Слід викликати перед завершенням програми (defer одразу
+// після openGeoCache), щоб не втратити останні зміни.
+func (c *GeoCache) Close() {
+	close(c.stop)
+	<-c.done
+}
+
+func normalizeCacheKey(query string) string {
+	return strings.ToLower(strings.TrimSpace(query))
+}
+
+// Get розбирає закешоване значення під ключем query у out, якщо запис
+// є і ще не протух. Повертає false, якщо кешу немає (або він прострочений).
+func (c *GeoCache) Get(query string, out interface{}) (bool, error) {
+	c.mu.Lock()
+	record, ok := c.entries[normalizeCacheKey(query)]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(record.ExpiresAt) {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(record.Value, out); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set зберігає значення під нормалізованим ключем query. Запис на диск
+// не відбувається негайно — кеш позначається як dirty і буде збережений
+// фоновим воркером (див. flushLoop).
+func (c *GeoCache) Set(query string, value interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxSize > 0 && len(c.entries) >= c.maxSize {
+		c.evictOldestLocked()
+	}
+
+	c.entries[normalizeCacheKey(query)] = cacheRecord{
+		Value:     data,
+		ExpiresAt: time.Now().Add(c.ttl),
+	}
+	c.dirty = true
+	return nil
+}
+
+func (c *GeoCache) evictOldestLocked() {
+	var oldestKey string
+	var oldestExpiry time.Time
+	for key, record := range c.entries {
+		if oldestKey == "" || record.ExpiresAt.Before(oldestExpiry) {
+			oldestKey = key
+			oldestExpiry = record.ExpiresAt
+		}
+	}
+	if oldestKey != "" {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// Clear видаляє всі записи кешу, і на диску, і в памʼяті.
+func (c *GeoCache) Clear() error {
+	c.mu.Lock()
+	c.entries = map[string]cacheRecord{}
+	data, err := json.MarshalIndent(c.entries, "", "  ")
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0644)
+}
+
+// Stats повертає загальну кількість записів та кількість протухлих.
+func (c *GeoCache) Stats() (total, expired int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for _, record := range c.entries {
+		total++
+		if now.After(record.ExpiresAt) {
+			expired++
+		}
+	}
+	return total, expired
+}
+
+// CachingGeocoder огортає будь-який Geocoder дисковим кешем: результат
+// запозичується з кешу, якщо він є, інакше запит виконується та
+// результат кешується. Кеш-попадання позначається LocationInfo.FromCache,
+// щоб виклик міг показати користувачу позначку "(cached)".
+type CachingGeocoder struct {
+	Inner geocoder.Geocoder
+	Cache *GeoCache
+}
+
+func (g *CachingGeocoder) Geocode(ctx context.Context, query string) (*geocoder.LocationInfo, error) {
+	var location geocoder.LocationInfo
+	if ok, _ := g.Cache.Get(query, &location); ok {
+		location.FromCache = true
+		return &location, nil
+	}
+
+	result, err := g.Inner.Geocode(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.Cache.Set(query, result); err != nil {
+		return nil, fmt.Errorf("помилка запису кешу: %v", err)
+	}
+
+	return result, nil
+}
+
+// cachedReverseGeocode — обгортка над reverseGeocode, що кешує
+// результат за ключем "rev:<lat>,<lon>", так само як CachingGeocoder
+// робить це для прямого геокодування.
+func cachedReverseGeocode(ctx context.Context, cache *GeoCache, lat, lon float64, accessToken string) (*geocoder.LocationInfo, error) {
+	key := fmt.Sprintf("rev:%.6f,%.6f", lat, lon)
+
+	var location geocoder.LocationInfo
+	if ok, _ := cache.Get(key, &location); ok {
+		location.FromCache = true
+		return &location, nil
+	}
+
+	result, err := reverseGeocode(ctx, lat, lon, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Set(key, result); err != nil {
+		return nil, fmt.Errorf("помилка запису кешу: %v", err)
+	}
+
+	return result, nil
+}
+
+// cachedGetRoute — обгортка над GetRoute, що кешує результат за ключем,
+// який включає профіль, прапорці Steps/Alternatives/Annotations та
+// координати точок (див. routeCacheKey).
+func cachedGetRoute(ctx context.Context, cache *GeoCache, points []*geocoder.LocationInfo, opts RouteOptions, accessToken string) (*RouteResult, error) {
+	key := routeCacheKey(points, opts)
+
+	var result RouteResult
+	if ok, _ := cache.Get(key, &result); ok {
+		result.Primary.FromCache = true
+		return &result, nil
+	}
+
+	route, err := GetRoute(ctx, points, opts, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cache.Set(key, route); err != nil {
+		return nil, fmt.Errorf("помилка запису кешу: %v", err)
+	}
+
+	return route, nil
+}
+
+// routeCacheKey включає в ключ не лише точки й профіль, а й усі
+// параметри, що впливають на форму відповіді (Steps, Alternatives,
+// Annotations) — інакше запит з іншими прапорцями для тих самих точок
+// повернув би з кешу відповідь, побудовану під старі прапорці.
+func routeCacheKey(points []*geocoder.LocationInfo, opts RouteOptions) string {
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.6f,%.6f", p.Longitude, p.Latitude)
+	}
+	return fmt.Sprintf("route:%s:steps=%t:alternatives=%t:annotations=%s:%s",
+		opts.Profile, opts.Steps, opts.Alternatives, strings.Join(opts.Annotations, ","), strings.Join(coords, ";"))
+}
@@ -0,0 +1,543 @@
+// Package geocoder надає уніфікований доступ до кількох провайдерів
+// геокодування (Mapbox, Photon, Google, Amap, Tencent) через спільний
+// інтерфейс Geocoder, з ланцюжком пріоритетів та перемиканням на
+// наступного провайдера у разі помилки чи порожнього результату.
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LocationInfo — уніфіковане представлення результату геокодування,
+// однакове для всіх провайдерів (Mapbox, Photon, Google, Amap, Tencent).
+type LocationInfo struct {
+	Latitude     float64
+	Longitude    float64
+	Country      string
+	CountryCode  string
+	Region       string
+	City         string
+	District     string
+	Street       string
+	StreetNumber string
+	PlaceName    string
+	FromCache    bool
+}
+
+// GeocodeResponse, Feature та Context описують відповідь Mapbox
+// Geocoding API; вони ж використовуються для розбору відповіді
+// зворотного геокодування, оскільки формат JSON однаковий.
+type GeocodeResponse struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+type Feature struct {
+	Type      string    `json:"type"`
+	PlaceName string    `json:"place_name"`
+	Text      string    `json:"text"`
+	Address   string    `json:"address"`
+	Center    []float64 `json:"center"`
+	Context   []Context `json:"context"`
+	PlaceType []string  `json:"place_type"`
+}
+
+type Context struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	ShortCode string `json:"short_code"`
+}
+
+// Geocoder перетворює текстову адресу на координати та структуровану
+// інформацію про місце. Кожен провайдер геокодування реалізує цей
+// інтерфейс по-своєму, відповідно до формату власного API.
+type Geocoder interface {
+	Geocode(ctx context.Context, query string) (*LocationInfo, error)
+}
+
+// ProviderConfig описує налаштування одного провайдера геокодування
+// у config.json: токен доступу, базовий URL (якщо відрізняється від
+// типового) та вагу, яка визначає пріоритет у ланцюжку Chain.
+type ProviderConfig struct {
+	Token   string `json:"token"`
+	BaseURL string `json:"base_url"`
+	Weight  int    `json:"weight"`
+}
+
+// GeocoderConfig зберігає налаштування всіх провайдерів та порядок,
+// у якому Chain їх опитує.
+type GeocoderConfig struct {
+	Providers map[string]ProviderConfig `json:"providers"`
+	Chain     []string                  `json:"chain"`
+}
+
+// LoadGeocoderConfig читає блок "geocoders" з config.json.
+func LoadGeocoderConfig() *GeocoderConfig {
+	data, _ := os.ReadFile("config.json")
+
+	var raw struct {
+		Geocoders *GeocoderConfig `json:"geocoders"`
+	}
+	json.Unmarshal(data, &raw)
+
+	if raw.Geocoders == nil {
+		return &GeocoderConfig{Providers: map[string]ProviderConfig{}}
+	}
+	return raw.Geocoders
+}
+
+// NewChainFromConfig будує ChainGeocoder із провайдерів, перелічених
+// у cfg.Chain (у порядку пріоритету). Якщо cfg.Chain не задано, порядок
+// визначається за Weight (спадання) серед провайдерів, які явно описані
+// у cfg.Providers — провайдер, якого немає в config.json, до ланцюжка
+// не додається. Провайдер без токена (там, де токен обов'язковий)
+// пропускається.
+func NewChainFromConfig(cfg *GeocoderConfig) *ChainGeocoder {
+	chain := &ChainGeocoder{}
+
+	order := cfg.Chain
+	if len(order) == 0 {
+		order = configuredProviderNamesByWeight(cfg)
+	}
+
+	for _, name := range order {
+		pc := cfg.Providers[name]
+		switch name {
+		case "mapbox":
+			if pc.Token != "" {
+				chain.Providers = append(chain.Providers, &MapboxGeocoder{AccessToken: pc.Token})
+			}
+		case "photon":
+			baseURL := pc.BaseURL
+			if env := os.Getenv("PEACH_PHOTON_URL"); env != "" {
+				baseURL = env
+			}
+			if baseURL == "" {
+				baseURL = "https://photon.komoot.io"
+			}
+			chain.Providers = append(chain.Providers, &PhotonGeocoder{BaseURL: baseURL})
+		case "google":
+			if pc.Token != "" {
+				chain.Providers = append(chain.Providers, &GoogleGeocoder{APIKey: pc.Token})
+			}
+		case "amap":
+			if pc.Token != "" {
+				chain.Providers = append(chain.Providers, &AmapGeocoder{Key: pc.Token})
+			}
+		case "tencent":
+			if pc.Token != "" {
+				chain.Providers = append(chain.Providers, &TencentGeocoder{Key: pc.Token})
+			}
+		}
+	}
+
+	return chain
+}
+
+// configuredProviderNamesByWeight повертає імена провайдерів, явно
+// описаних у cfg.Providers, відсортовані за спаданням Weight (вищий
+// пріоритет — раніше в списку; порядок ключів мапи не визначений, тому
+// для детермінованості рівні ваги впорядковуються за іменем).
+func configuredProviderNamesByWeight(cfg *GeocoderConfig) []string {
+	names := make([]string, 0, len(cfg.Providers))
+	for name := range cfg.Providers {
+		names = append(names, name)
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		wi, wj := cfg.Providers[names[i]].Weight, cfg.Providers[names[j]].Weight
+		if wi != wj {
+			return wi > wj
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+// ChainGeocoder опитує провайдерів по черзі у порядку пріоритету й
+// повертає перший успішний результат. Якщо провайдер повернув помилку
+// або порожній результат, відбувається перехід до наступного.
+type ChainGeocoder struct {
+	Providers []Geocoder
+}
+
+func (c *ChainGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	if len(c.Providers) == 0 {
+		return nil, fmt.Errorf("не налаштовано жодного провайдера геокодування")
+	}
+
+	var lastErr error
+	for _, p := range c.Providers {
+		location, err := p.Geocode(ctx, query)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if location == nil {
+			continue
+		}
+		return location, nil
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("жоден провайдер не зміг геокодувати адресу: %v", lastErr)
+	}
+	return nil, fmt.Errorf("адресу не знайдено жодним провайдером")
+}
+
+// MapboxGeocoder використовує Mapbox Geocoding API.
+type MapboxGeocoder struct {
+	AccessToken string
+}
+
+func (m *MapboxGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	baseURL := "https://api.mapbox.com/geocoding/v5/mapbox.places/"
+	apiURL := fmt.Sprintf("%s%s.json?access_token=%s", baseURL, url.QueryEscape(query), m.AccessToken)
+
+	body, err := httpGetContext(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var geocodeResp GeocodeResponse
+	if err := json.Unmarshal(body, &geocodeResp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON (mapbox): %v", err)
+	}
+	if len(geocodeResp.Features) == 0 {
+		return nil, fmt.Errorf("адресу не знайдено (mapbox)")
+	}
+
+	feature := geocodeResp.Features[0]
+	location := &LocationInfo{
+		Longitude: feature.Center[0],
+		Latitude:  feature.Center[1],
+		PlaceName: feature.PlaceName,
+		Country:   "Невідомо",
+		Region:    "Невідомо",
+		City:      "Невідомо",
+	}
+
+	for _, fctx := range feature.Context {
+		switch {
+		case strings.HasPrefix(fctx.ID, "country"):
+			location.Country = fctx.Text
+			location.CountryCode = fctx.ShortCode
+		case strings.HasPrefix(fctx.ID, "region"):
+			location.Region = fctx.Text
+		case strings.HasPrefix(fctx.ID, "district"):
+			location.District = fctx.Text
+		case strings.HasPrefix(fctx.ID, "place"):
+			location.City = fctx.Text
+		case strings.HasPrefix(fctx.ID, "neighborhood"):
+			if location.District == "" {
+				location.District = fctx.Text
+			}
+		}
+	}
+
+	if feature.PlaceType != nil && Contains(feature.PlaceType, "address") {
+		location.Street = feature.Text
+		location.StreetNumber = feature.Address
+	}
+
+	return location, nil
+}
+
+// Contains повідомляє, чи міститься target серед items. Спільна
+// допоміжна функція для розбору provider-специфічних типів адреси.
+func Contains(items []string, target string) bool {
+	for _, it := range items {
+		if it == target {
+			return true
+		}
+	}
+	return false
+}
+
+// PhotonGeocoder використовує Photon (геокодування на основі OpenStreetMap),
+// базовий URL якого можна перевизначити через PEACH_PHOTON_URL.
+type PhotonGeocoder struct {
+	BaseURL string
+}
+
+type photonResponse struct {
+	Features []photonFeature `json:"features"`
+}
+
+type photonFeature struct {
+	Geometry struct {
+		Coordinates []float64 `json:"coordinates"`
+	} `json:"geometry"`
+	Properties struct {
+		Country     string `json:"country"`
+		CountryCode string `json:"countrycode"`
+		State       string `json:"state"`
+		City        string `json:"city"`
+		District    string `json:"district"`
+		Street      string `json:"street"`
+		HouseNumber string `json:"housenumber"`
+		Name        string `json:"name"`
+	} `json:"properties"`
+}
+
+func (p *PhotonGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	apiURL := fmt.Sprintf("%s/api?q=%s&limit=1", strings.TrimRight(p.BaseURL, "/"), url.QueryEscape(query))
+
+	body, err := httpGetContext(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp photonResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON (photon): %v", err)
+	}
+	if len(resp.Features) == 0 || len(resp.Features[0].Geometry.Coordinates) < 2 {
+		return nil, fmt.Errorf("адресу не знайдено (photon)")
+	}
+
+	f := resp.Features[0]
+	return &LocationInfo{
+		Longitude:    f.Geometry.Coordinates[0],
+		Latitude:     f.Geometry.Coordinates[1],
+		Country:      f.Properties.Country,
+		CountryCode:  strings.ToUpper(f.Properties.CountryCode),
+		Region:       f.Properties.State,
+		City:         f.Properties.City,
+		District:     f.Properties.District,
+		Street:       f.Properties.Street,
+		StreetNumber: f.Properties.HouseNumber,
+		PlaceName:    f.Properties.Name,
+	}, nil
+}
+
+// GoogleGeocoder використовує Google Maps Geocoding API.
+type GoogleGeocoder struct {
+	APIKey string
+}
+
+type googleResponse struct {
+	Status  string `json:"status"`
+	Results []struct {
+		FormattedAddress string `json:"formatted_address"`
+		Geometry         struct {
+			Location struct {
+				Lat float64 `json:"lat"`
+				Lng float64 `json:"lng"`
+			} `json:"location"`
+		} `json:"geometry"`
+		AddressComponents []struct {
+			LongName string   `json:"long_name"`
+			Types    []string `json:"types"`
+		} `json:"address_components"`
+	} `json:"results"`
+}
+
+func (g *GoogleGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	apiURL := fmt.Sprintf("https://maps.googleapis.com/maps/api/geocode/json?address=%s&key=%s",
+		url.QueryEscape(query), g.APIKey)
+
+	body, err := httpGetContext(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp googleResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON (google): %v", err)
+	}
+	if resp.Status != "OK" || len(resp.Results) == 0 {
+		return nil, fmt.Errorf("адресу не знайдено (google): %s", resp.Status)
+	}
+
+	r := resp.Results[0]
+	location := &LocationInfo{
+		Longitude: r.Geometry.Location.Lng,
+		Latitude:  r.Geometry.Location.Lat,
+		PlaceName: r.FormattedAddress,
+	}
+
+	for _, comp := range r.AddressComponents {
+		switch {
+		case Contains(comp.Types, "country"):
+			location.Country = comp.LongName
+		case Contains(comp.Types, "administrative_area_level_1"):
+			location.Region = comp.LongName
+		case Contains(comp.Types, "locality"):
+			location.City = comp.LongName
+		case Contains(comp.Types, "sublocality"):
+			location.District = comp.LongName
+		case Contains(comp.Types, "route"):
+			location.Street = comp.LongName
+		case Contains(comp.Types, "street_number"):
+			location.StreetNumber = comp.LongName
+		}
+	}
+
+	return location, nil
+}
+
+// AmapGeocoder використовує Amap (高德地图) Geocoding API, що зручно
+// для адрес на материковому Китаї, де Mapbox має слабке покриття.
+type AmapGeocoder struct {
+	Key string
+}
+
+type amapResponse struct {
+	Status   string `json:"status"`
+	Geocodes []struct {
+		FormattedAddress string `json:"formatted_address"`
+		Country          string `json:"country"`
+		Province         string `json:"province"`
+		City             string `json:"city"`
+		District         string `json:"district"`
+		Street           string `json:"street"`
+		Number           string `json:"number"`
+		Location         string `json:"location"`
+	} `json:"geocodes"`
+}
+
+func (a *AmapGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	apiURL := fmt.Sprintf("https://restapi.amap.com/v3/geocode/geo?address=%s&key=%s",
+		url.QueryEscape(query), a.Key)
+
+	body, err := httpGetContext(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp amapResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON (amap): %v", err)
+	}
+	if resp.Status != "1" || len(resp.Geocodes) == 0 {
+		return nil, fmt.Errorf("адресу не знайдено (amap)")
+	}
+
+	g := resp.Geocodes[0]
+	lon, lat, err := parseAmapLocation(g.Location)
+	if err != nil {
+		return nil, fmt.Errorf("помилка розбору координат (amap): %v", err)
+	}
+
+	city := g.City
+	if city == "" {
+		city = g.Province
+	}
+
+	return &LocationInfo{
+		Longitude:    lon,
+		Latitude:     lat,
+		Country:      g.Country,
+		Region:       g.Province,
+		City:         city,
+		District:     g.District,
+		Street:       g.Street,
+		StreetNumber: g.Number,
+		PlaceName:    g.FormattedAddress,
+	}, nil
+}
+
+func parseAmapLocation(s string) (lon, lat float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("неочікуваний формат координат: %q", s)
+	}
+	lon, err = strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err = strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return lon, lat, nil
+}
+
+// TencentGeocoder використовує Tencent (QQ Map) Geocoding API.
+type TencentGeocoder struct {
+	Key string
+}
+
+type tencentResponse struct {
+	Status int `json:"status"`
+	Result struct {
+		Title    string `json:"title"`
+		Location struct {
+			Lat float64 `json:"lat"`
+			Lng float64 `json:"lng"`
+		} `json:"location"`
+		AddressComponents struct {
+			Nation       string `json:"nation"`
+			Province     string `json:"province"`
+			City         string `json:"city"`
+			District     string `json:"district"`
+			Street       string `json:"street"`
+			StreetNumber string `json:"street_number"`
+		} `json:"address_components"`
+	} `json:"result"`
+}
+
+func (t *TencentGeocoder) Geocode(ctx context.Context, query string) (*LocationInfo, error) {
+	apiURL := fmt.Sprintf("https://apis.map.qq.com/ws/geocoder/v1/?address=%s&key=%s",
+		url.QueryEscape(query), t.Key)
+
+	body, err := httpGetContext(ctx, apiURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp tencentResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON (tencent): %v", err)
+	}
+	if resp.Status != 0 {
+		return nil, fmt.Errorf("адресу не знайдено (tencent)")
+	}
+
+	ac := resp.Result.AddressComponents
+	return &LocationInfo{
+		Longitude:    resp.Result.Location.Lng,
+		Latitude:     resp.Result.Location.Lat,
+		Country:      ac.Nation,
+		Region:       ac.Province,
+		City:         ac.City,
+		District:     ac.District,
+		Street:       ac.Street,
+		StreetNumber: ac.StreetNumber,
+		PlaceName:    resp.Result.Title,
+	}, nil
+}
+
+// httpGetContext виконує GET-запит із прив'язкою до контексту та
+// повертає тіло відповіді. Спільна допоміжна функція для всіх
+// провайдерів геокодування.
+func httpGetContext(ctx context.Context, apiURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("помилка створення запиту: %v", err)
+	}
+
+	resp, err := SharedHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("помилка HTTP запиту: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання відповіді: %v", err)
+	}
+
+	return body, nil
+}
@@ -0,0 +1,159 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// HTTPConfig описує налаштування повторних спроб HTTP-запитів у
+// config.json: кількість спроб, базову затримку та тайм-аут на запит.
+type HTTPConfig struct {
+	MaxTries    int `json:"max_tries"`
+	BaseDelayMs int `json:"base_delay_ms"`
+	TimeoutMs   int `json:"timeout_ms"`
+}
+
+func loadHTTPConfig() HTTPConfig {
+	data, _ := os.ReadFile("config.json")
+
+	var raw struct {
+		HTTP *HTTPConfig `json:"http"`
+	}
+	json.Unmarshal(data, &raw)
+
+	cfg := HTTPConfig{MaxTries: 3, BaseDelayMs: 1000, TimeoutMs: 10000}
+	if raw.HTTP != nil {
+		if raw.HTTP.MaxTries > 0 {
+			cfg.MaxTries = raw.HTTP.MaxTries
+		}
+		if raw.HTTP.BaseDelayMs > 0 {
+			cfg.BaseDelayMs = raw.HTTP.BaseDelayMs
+		}
+		if raw.HTTP.TimeoutMs > 0 {
+			cfg.TimeoutMs = raw.HTTP.TimeoutMs
+		}
+	}
+	return cfg
+}
+
+// retryingTransport — http.RoundTripper, що повторює запит із
+// експоненціальною затримкою (подвоюється щоразу, починаючи з
+// baseDelay) та джиттером ±500мс при помилках транспорту або
+// відповідях 5xx/429. Поважає заголовок Retry-After і негайно
+// припиняє спроби, якщо контекст скасовано.
+type retryingTransport struct {
+	next      http.RoundTripper
+	maxTries  int
+	baseDelay time.Duration
+}
+
+func newRetryingClient(cfg HTTPConfig) *http.Client {
+	return &http.Client{
+		Timeout: time.Duration(cfg.TimeoutMs) * time.Millisecond,
+		Transport: &retryingTransport{
+			next:      http.DefaultTransport,
+			maxTries:  cfg.MaxTries,
+			baseDelay: time.Duration(cfg.BaseDelayMs) * time.Millisecond,
+		},
+	}
+}
+
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	maxTries := t.maxTries
+	if maxTries < 1 {
+		maxTries = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxTries; attempt++ {
+		if attempt > 0 {
+			wait := t.backoff(attempt) + jitter()
+			if err := sleepOrCancel(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+
+		resp, err := t.next.RoundTrip(req)
+		if err != nil {
+			lastErr = err
+			if req.Context().Err() != nil {
+				return nil, req.Context().Err()
+			}
+			continue
+		}
+
+		if resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return resp, nil
+		}
+
+		if attempt == maxTries-1 {
+			return resp, nil
+		}
+
+		if retryAfter := parseRetryAfter(resp.Header.Get("Retry-After")); retryAfter > 0 {
+			resp.Body.Close()
+			if err := sleepOrCancel(req.Context(), retryAfter); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		resp.Body.Close()
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("вичерпано спроби запиту: %v", lastErr)
+	}
+	return nil, fmt.Errorf("вичерпано спроби запиту")
+}
+
+func (t *retryingTransport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+func jitter() time.Duration {
+	return time.Duration(rand.Intn(1000)-500) * time.Millisecond
+}
+
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := http.ParseTime(header); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+func sleepOrCancel(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SharedHTTPClient — спільний HTTP-клієнт із повторними спробами,
+// яким користуються всі провайдери геокодування пакета, а також
+// виклики зворотного геокодування та побудови маршруту в main.
+var SharedHTTPClient = newRetryingClient(loadHTTPConfig())
@@ -2,17 +2,35 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"os"
 	"strings"
+
+	"github.com/skybloodd/MapBox/geocoder"
 )
 
 var MAPBOX_ACCESS_TOKEN = loadToken()
 
+var (
+	batchInput   = flag.String("batch", "", "шлях до CSV/текстового файлу з адресами для пакетного геокодування")
+	batchOutput  = flag.String("out", "output.csv", "шлях до вихідного CSV для режиму -batch")
+	batchWorkers = flag.Int("workers", 4, "кількість одночасних воркерів у режимі -batch")
+	batchFormat  = flag.String("format", "decimal", "формат координат у -batch: decimal або dms")
+	batchGeoJSON = flag.String("geojson", "", "якщо задано, додатково зберігає результати -batch як GeoJSON FeatureCollection")
+
+	cacheClear = flag.Bool("cache-clear", false, "очистити дисковий кеш геокодування та вийти")
+	cacheStats = flag.Bool("cache-stats", false, "показати статистику дискового кешу геокодування та вийти")
+
+	routeProfile      = flag.String("profile", "driving", "профіль маршруту: driving, driving-traffic, walking або cycling")
+	routeSteps        = flag.Bool("steps", false, "додати покрокові інструкції до маршруту")
+	routeAlternatives = flag.Bool("alternatives", false, "запитувати альтернативні маршрути")
+	routeAnnotations  = flag.String("annotations", "", "анотації маршруту через кому, наприклад duration,distance,speed")
+	routeGeoJSON      = flag.String("route-geojson", "", "якщо задано, зберігає геометрію маршруту як GeoJSON LineString")
+)
+
 func loadToken() string {
 	data, _ := os.ReadFile("config.json")
 
@@ -22,183 +40,191 @@ func loadToken() string {
 	return config["mapbox_access_token"]
 }
 
-type LocationInfo struct {
-	Latitude  float64
-	Longitude float64
-	Country   string
-	Region    string
-	City      string
-	PlaceName string
-}
-
-type GeocodeResponse struct {
-	Type     string    `json:"type"`
-	Features []Feature `json:"features"`
-}
-
-type Feature struct {
-	Type      string    `json:"type"`
-	PlaceName string    `json:"place_name"`
-	Center    []float64 `json:"center"`
-	Context   []Context `json:"context"`
-}
-
-type Context struct {
-	ID   string `json:"id"`
-	Text string `json:"text"`
-}
-
-type DirectionsResponse struct {
-	Routes []Route `json:"routes"`
-}
-
-type Route struct {
-	Distance float64  `json:"distance"`
-	Duration float64  `json:"duration"`
-	Geometry Geometry `json:"geometry"`
-}
-
+// Geometry — геометрія маршруту (список координат), повертана Mapbox
+// Directions API.
 type Geometry struct {
 	Coordinates [][]float64 `json:"coordinates"`
 }
 
-func geocodeAddress(address string, accessToken string) (*LocationInfo, error) {
-	baseURL := "https://api.mapbox.com/geocoding/v5/mapbox.places/"
-	encodedAddress := url.QueryEscape(address)
-	apiURL := fmt.Sprintf("%s%s.json?access_token=%s", baseURL, encodedAddress, accessToken)
-
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return nil, fmt.Errorf("помилка HTTP запиту: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("помилка читання відповіді: %v", err)
-	}
-
-	var geocodeResp GeocodeResponse
-	err = json.Unmarshal(body, &geocodeResp)
-	if err != nil {
-		return nil, fmt.Errorf("помилка парсингу JSON: %v", err)
+func printLocation(name string, location *geocoder.LocationInfo) {
+	cached := ""
+	if location.FromCache {
+		cached = " (cached)"
 	}
-
-	if len(geocodeResp.Features) == 0 {
-		return nil, fmt.Errorf("адресу не знайдено")
+	fmt.Printf("\n%s%s:\n", name, cached)
+	fmt.Printf("  Країна: %s\n", location.Country)
+	fmt.Printf("  Область: %s\n", location.Region)
+	fmt.Printf("  Місто: %s\n", location.City)
+	if location.District != "" {
+		fmt.Printf("  Район: %s\n", location.District)
 	}
-
-	feature := geocodeResp.Features[0]
-
-	location := &LocationInfo{
-		Longitude: feature.Center[0],
-		Latitude:  feature.Center[1],
-		PlaceName: feature.PlaceName,
-		Country:   "Невідомо",
-		Region:    "Невідомо",
-		City:      "Невідомо",
+	if location.Street != "" {
+		fmt.Printf("  Вулиця: %s %s\n", location.Street, location.StreetNumber)
 	}
+	fmt.Printf("  Широта: %.6f\n", location.Latitude)
+	fmt.Printf("  Довгота: %.6f\n", location.Longitude)
+	fmt.Printf("  Повна адреса: %s\n", location.PlaceName)
+}
 
-	for _, ctx := range feature.Context {
-		if strings.HasPrefix(ctx.ID, "country") {
-			location.Country = ctx.Text
-		} else if strings.HasPrefix(ctx.ID, "region") {
-			location.Region = ctx.Text
-		} else if strings.HasPrefix(ctx.ID, "place") {
-			location.City = ctx.Text
-		}
+// newGeocoder будує ланцюжок провайдерів із config.json. Якщо там не
+// описано жодного геокодера, використовується лише Mapbox із токеном
+// за замовчуванням, щоб не ламати наявні налаштування. Повертається
+// конкретний *CachingGeocoder (а не інтерфейс geocoder.Geocoder), щоб
+// виклики зворотного геокодування та побудови маршруту могли дістатись
+// до того самого GeoCache і теж кешуватись.
+func newGeocoder() *CachingGeocoder {
+	cfg := geocoder.LoadGeocoderConfig()
+	chain := geocoder.NewChainFromConfig(cfg)
+
+	if len(chain.Providers) == 0 && MAPBOX_ACCESS_TOKEN != "" {
+		chain.Providers = append(chain.Providers, &geocoder.MapboxGeocoder{AccessToken: MAPBOX_ACCESS_TOKEN})
 	}
 
-	return location, nil
+	cache := openGeoCache(loadCacheConfig())
+	return &CachingGeocoder{Inner: chain, Cache: cache}
 }
 
-func getDistance(start, end *LocationInfo, accessToken string) (float64, float64, error) {
-	baseURL := "https://api.mapbox.com/directions/v5/mapbox/driving/"
-	coordinates := fmt.Sprintf("%.6f,%.6f;%.6f,%.6f",
-		start.Longitude, start.Latitude,
-		end.Longitude, end.Latitude)
-	apiURL := fmt.Sprintf("%s%s?access_token=%s&geometries=geojson", baseURL, coordinates, accessToken)
-
-	resp, err := http.Get(apiURL)
-	if err != nil {
-		return 0, 0, fmt.Errorf("помилка HTTP запиту: %v", err)
-	}
-	defer resp.Body.Close()
+func readLine(reader *bufio.Reader) string {
+	line, _ := reader.ReadString('\n')
+	return strings.TrimSpace(line)
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return 0, 0, fmt.Errorf("помилка читання відповіді: %v", err)
+// main обробляє точки та маршрут у циклі: після невдалого проходу
+// (поганий ввід, адресу не знайдено, мало точок для маршруту тощо) чи
+// успішного завершення він повертає користувача до вибору режиму
+// замість виходу. Раніше це робилось рекурсивним викликом main(), який
+// ніколи не повертався і необмежено нарощував стек із кожним проходом.
+func main() {
+	if !flag.Parsed() {
+		flag.Parse()
 	}
 
-	var directionsResp DirectionsResponse
-	err = json.Unmarshal(body, &directionsResp)
-	if err != nil {
-		return 0, 0, fmt.Errorf("помилка парсингу JSON: %v", err)
+	if *cacheClear {
+		cache := openGeoCache(loadCacheConfig())
+		defer cache.Close()
+		if err := cache.Clear(); err != nil {
+			fmt.Printf("Помилка очищення кешу: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("Кеш геокодування очищено.")
+		return
 	}
 
-	if len(directionsResp.Routes) == 0 {
-		return 0, 0, fmt.Errorf("маршрут не знайдено")
+	if *cacheStats {
+		cache := openGeoCache(loadCacheConfig())
+		defer cache.Close()
+		total, expired := cache.Stats()
+		fmt.Printf("Записів у кеші: %d (протухлих: %d)\n", total, expired)
+		return
 	}
 
-	distance := directionsResp.Routes[0].Distance
-	duration := directionsResp.Routes[0].Duration
+	geo := newGeocoder()
+	defer geo.Cache.Close()
+	ctx := context.Background()
 
-	return distance, duration, nil
-}
+	if *batchInput != "" {
+		fmt.Printf("\nПакетне геокодування %q (воркерів: %d)...\n", *batchInput, *batchWorkers)
+		if err := runBatch(ctx, geo, *batchInput, *batchOutput, *batchWorkers, *batchFormat, *batchGeoJSON); err != nil {
+			fmt.Printf("Помилка пакетного геокодування: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Готово. Результати збережено у %q.\n", *batchOutput)
+		return
+	}
 
-func printLocation(name string, location *LocationInfo) {
-	fmt.Printf("\n%s:\n", name)
-	fmt.Printf("  Країна: %s\n", location.Country)
-	fmt.Printf("  Область: %s\n", location.Region)
-	fmt.Printf("  Місто: %s\n", location.City)
-	fmt.Printf("  Широта: %.6f\n", location.Latitude)
-	fmt.Printf("  Довгота: %.6f\n", location.Longitude)
-	fmt.Printf("  Повна адреса: %s\n", location.PlaceName)
-}
+	reader := bufio.NewReader(os.Stdin)
 
-func printDistance(distance, duration float64) {
-	fmt.Printf("\nІнформація про маршрут:\n")
-	fmt.Printf("  Відстань: %.2f метрів (%.2f км)\n", distance, distance/1000)
-	fmt.Printf("  Тривалість: %.0f секунд (%.2f хвилин)\n", duration, duration/60)
-}
+	for {
+		fmt.Println("\nОберіть режим:")
+		fmt.Println("  1 — геокодування за адресою")
+		fmt.Println("  2 — зворотне геокодування за координатами")
+		mode := readLine(reader)
+
+		var points []*geocoder.LocationInfo
+		var failed bool
+
+		if mode == "2" {
+			for i := 1; ; i++ {
+				fmt.Printf("\nВведіть координати точки %d (наприклад, '50.4501, 30.5234'), або порожній рядок щоб зупинитись:\n", i)
+				raw := readLine(reader)
+				if raw == "" {
+					break
+				}
+
+				lat, lon, parseErr := parseCoordinatePair(raw)
+				if parseErr != nil {
+					fmt.Printf("Помилка розбору координат: %v\n\n\n", parseErr)
+					failed = true
+					break
+				}
+
+				location, err := cachedReverseGeocode(ctx, geo.Cache, lat, lon, MAPBOX_ACCESS_TOKEN)
+				if err != nil {
+					fmt.Printf("Помилка зворотного геокодування точки %d: %v\n\n\n", i, err)
+					failed = true
+					break
+				}
+				printLocation(fmt.Sprintf("Точка %d", i), location)
+				points = append(points, location)
+			}
+		} else {
+			for i := 1; ; i++ {
+				fmt.Printf("\nВведіть адресу точки %d (наприклад, 'м. Київ, вул. Хрещатик'), або порожній рядок щоб зупинитись:\n", i)
+				address := readLine(reader)
+				if address == "" {
+					break
+				}
+
+				location, err := geo.Geocode(ctx, address)
+				if err != nil {
+					fmt.Printf("Помилка геокодування точки %d: %v\n\n\n", i, err)
+					failed = true
+					break
+				}
+				printLocation(fmt.Sprintf("Точка %d", i), location)
+				points = append(points, location)
+			}
+		}
 
-func readLine(reader *bufio.Reader) string {
-	line, _ := reader.ReadString('\n')
-	return strings.TrimSpace(line)
-}
+		if failed {
+			continue
+		}
 
-func main() {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Println("\nВведіть першу адресу або місце (наприклад, 'м. Київ, вул. Хрещатик'):")
-	address1 := readLine(reader)
+		if len(points) < 2 {
+			fmt.Println("\nДля побудови маршруту потрібно щонайменше дві точки.\n\n\n")
+			continue
+		}
 
-	fmt.Println("Введіть другу адресу або місце:")
-	address2 := readLine(reader)
+		var annotations []string
+		if *routeAnnotations != "" {
+			annotations = strings.Split(*routeAnnotations, ",")
+		}
 
-	fmt.Println("\nОбробка першої адреси...")
-	location1, err := geocodeAddress(address1, MAPBOX_ACCESS_TOKEN)
-	if err != nil {
-		fmt.Printf("Помилка геокодування першої адреси: %v\n\n\n", err)
-		main()
-	}
-	printLocation("Точка 1", location1)
+		fmt.Println("\nОбчислення маршруту...")
+		route, err := cachedGetRoute(ctx, geo.Cache, points, RouteOptions{
+			Profile:      *routeProfile,
+			Steps:        *routeSteps,
+			Alternatives: *routeAlternatives,
+			Annotations:  annotations,
+		}, MAPBOX_ACCESS_TOKEN)
+		if err != nil {
+			fmt.Printf("Помилка отримання маршруту: %v\n\n\n", err)
+			continue
+		}
+		printRoute(&route.Primary)
+		for i, alt := range route.Alternatives {
+			fmt.Printf("\nАльтернативний маршрут %d:\n", i+1)
+			printRoute(&alt)
+		}
 
-	fmt.Println("\nОбробка другої адреси...")
-	location2, err := geocodeAddress(address2, MAPBOX_ACCESS_TOKEN)
-	if err != nil {
-		fmt.Printf("Помилка геокодування другої адреси: %v\n\n\n", err)
-		main()
-	}
-	printLocation("Точка 2", location2)
+		if *routeGeoJSON != "" {
+			if err := writeRouteGeoJSON(*routeGeoJSON, &route.Primary); err != nil {
+				fmt.Printf("Помилка запису GeoJSON маршруту: %v\n", err)
+			} else {
+				fmt.Printf("Геометрію маршруту збережено у %q.\n", *routeGeoJSON)
+			}
+		}
 
-	fmt.Println("\nОбчислення маршруту...")
-	distance, duration, err := getDistance(location1, location2, MAPBOX_ACCESS_TOKEN)
-	if err != nil {
-		fmt.Printf("Помилка отримання відстані: %v\n\n\n", err)
-		main()
+		fmt.Println("\n=== Робота завершена успішно ===\n\n\n")
 	}
-	printDistance(distance, duration)
-
-	fmt.Println("\n=== Робота завершена успішно ===\n\n\n")
-	main()
 }
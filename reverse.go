@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/skybloodd/MapBox/geocoder"
+)
+
+// reverseGeocode перетворює пару координат на людинозрозумілу адресу,
+// використовуючи Mapbox reverse geocoding (mapbox.places/{lon},{lat}.json).
+func reverseGeocode(ctx context.Context, lat, lon float64, accessToken string) (*geocoder.LocationInfo, error) {
+	baseURL := "https://api.mapbox.com/geocoding/v5/mapbox.places/"
+	coordinates := fmt.Sprintf("%.6f,%.6f", lon, lat)
+	apiURL := fmt.Sprintf("%s%s.json?access_token=%s", baseURL, url.QueryEscape(coordinates), accessToken)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("помилка створення запиту: %v", err)
+	}
+
+	resp, err := geocoder.SharedHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("помилка HTTP запиту: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("помилка читання відповіді: %v", err)
+	}
+
+	var geocodeResp geocoder.GeocodeResponse
+	if err := json.Unmarshal(body, &geocodeResp); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON: %v", err)
+	}
+	if len(geocodeResp.Features) == 0 {
+		return nil, fmt.Errorf("за цими координатами адресу не знайдено")
+	}
+
+	feature := geocodeResp.Features[0]
+	location := &geocoder.LocationInfo{
+		Latitude:  lat,
+		Longitude: lon,
+		PlaceName: feature.PlaceName,
+		Country:   "Невідомо",
+		Region:    "Невідомо",
+		City:      "Невідомо",
+	}
+
+	for _, ctx := range feature.Context {
+		switch {
+		case strings.HasPrefix(ctx.ID, "country"):
+			location.Country = ctx.Text
+			location.CountryCode = ctx.ShortCode
+		case strings.HasPrefix(ctx.ID, "region"):
+			location.Region = ctx.Text
+		case strings.HasPrefix(ctx.ID, "district"):
+			location.District = ctx.Text
+		case strings.HasPrefix(ctx.ID, "place"):
+			location.City = ctx.Text
+		case strings.HasPrefix(ctx.ID, "neighborhood"):
+			if location.District == "" {
+				location.District = ctx.Text
+			}
+		}
+	}
+
+	if feature.PlaceType != nil && geocoder.Contains(feature.PlaceType, "address") {
+		location.Street = feature.Text
+		location.StreetNumber = feature.Address
+	}
+
+	return location, nil
+}
+
+// parseCoordinatePair розбирає введений рядок у форматі
+// "широта, довгота" на пару float64.
+func parseCoordinatePair(raw string) (lat, lon float64, err error) {
+	parts := strings.Split(raw, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("очікується формат 'широта, довгота', отримано %q", raw)
+	}
+
+	lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некоректна широта: %v", err)
+	}
+	lon, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некоректна довгота: %v", err)
+	}
+
+	return lat, lon, nil
+}
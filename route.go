@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/skybloodd/MapBox/geocoder"
+)
+
+// RouteOptions налаштовує запит до Mapbox Directions: профіль
+// пересування, чи повертати покрокові інструкції та альтернативні
+// маршрути, а також які анотації додати до кожного кроку.
+type RouteOptions struct {
+	Profile      string
+	Steps        bool
+	Alternatives bool
+	Annotations  []string
+}
+
+// RouteStep — один крок маневру в межах ділянки (leg) маршруту.
+type RouteStep struct {
+	Distance     float64
+	Duration     float64
+	ManeuverType string
+	Instruction  string
+	Location     []float64
+}
+
+// RouteLeg — ділянка маршруту між двома послідовними точками.
+type RouteLeg struct {
+	Distance float64
+	Duration float64
+	Steps    []RouteStep
+}
+
+// RouteData — один варіант маршруту (основний або альтернативний).
+type RouteData struct {
+	Distance  float64
+	Duration  float64
+	Geometry  Geometry
+	Legs      []RouteLeg
+	FromCache bool
+}
+
+// RouteResult — відповідь на запит маршруту: основний варіант та,
+// якщо запитувались, альтернативні.
+type RouteResult struct {
+	Primary      RouteData
+	Alternatives []RouteData
+}
+
+type directionsManeuver struct {
+	Type        string    `json:"type"`
+	Instruction string    `json:"instruction"`
+	Location    []float64 `json:"location"`
+}
+
+type directionsStep struct {
+	Distance float64            `json:"distance"`
+	Duration float64            `json:"duration"`
+	Maneuver directionsManeuver `json:"maneuver"`
+}
+
+type directionsLeg struct {
+	Distance float64          `json:"distance"`
+	Duration float64          `json:"duration"`
+	Steps    []directionsStep `json:"steps"`
+}
+
+type directionsRoute struct {
+	Distance float64         `json:"distance"`
+	Duration float64         `json:"duration"`
+	Geometry Geometry        `json:"geometry"`
+	Legs     []directionsLeg `json:"legs"`
+}
+
+type richDirectionsResponse struct {
+	Routes []directionsRoute `json:"routes"`
+}
+
+// GetRoute будує маршрут через довільну кількість точок waypoints,
+// використовуючи Mapbox Directions API з вибраним профілем
+// пересування (driving, driving-traffic, walking, cycling).
+func GetRoute(ctx context.Context, points []*geocoder.LocationInfo, opts RouteOptions, accessToken string) (*RouteResult, error) {
+	if len(points) < 2 {
+		return nil, fmt.Errorf("для побудови маршруту потрібно щонайменше дві точки")
+	}
+
+	profile := opts.Profile
+	if profile == "" {
+		profile = "driving"
+	}
+
+	coords := make([]string, len(points))
+	for i, p := range points {
+		coords[i] = fmt.Sprintf("%.6f,%.6f", p.Longitude, p.Latitude)
+	}
+
+	baseURL := fmt.Sprintf("https://api.mapbox.com/directions/v5/mapbox/%s/", profile)
+	apiURL := fmt.Sprintf("%s%s?access_token=%s&geometries=geojson", baseURL, strings.Join(coords, ";"), accessToken)
+	apiURL += fmt.Sprintf("&steps=%t&alternatives=%t", opts.Steps, opts.Alternatives)
+	if len(opts.Annotations) > 0 {
+		apiURL += "&annotations=" + strings.Join(opts.Annotations, ",")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("помилка створення запиту: %v", err)
+	}
+
+	resp, err := geocoder.SharedHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("помилка HTTP запиту: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var parsed richDirectionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("помилка парсингу JSON: %v", err)
+	}
+	if len(parsed.Routes) == 0 {
+		return nil, fmt.Errorf("маршрут не знайдено")
+	}
+
+	result := &RouteResult{Primary: toRouteData(parsed.Routes[0])}
+	for _, alt := range parsed.Routes[1:] {
+		result.Alternatives = append(result.Alternatives, toRouteData(alt))
+	}
+
+	return result, nil
+}
+
+func toRouteData(r directionsRoute) RouteData {
+	data := RouteData{
+		Distance: r.Distance,
+		Duration: r.Duration,
+		Geometry: r.Geometry,
+	}
+
+	for _, leg := range r.Legs {
+		routeLeg := RouteLeg{Distance: leg.Distance, Duration: leg.Duration}
+		for _, step := range leg.Steps {
+			routeLeg.Steps = append(routeLeg.Steps, RouteStep{
+				Distance:     step.Distance,
+				Duration:     step.Duration,
+				ManeuverType: step.Maneuver.Type,
+				Instruction:  step.Maneuver.Instruction,
+				Location:     step.Maneuver.Location,
+			})
+		}
+		data.Legs = append(data.Legs, routeLeg)
+	}
+
+	return data
+}
+
+// printRoute друкує маршрут по ділянках (leg) з покроковими
+// інструкціями (якщо вони є) та підсумковою відстанню й тривалістю.
+func printRoute(route *RouteData) {
+	cached := ""
+	if route.FromCache {
+		cached = " (cached)"
+	}
+	fmt.Printf("\nІнформація про маршрут%s:\n", cached)
+	for i, leg := range route.Legs {
+		fmt.Printf("  Ділянка %d: %.2f км, %.0f хв\n", i+1, leg.Distance/1000, leg.Duration/60)
+		for _, step := range leg.Steps {
+			fmt.Printf("    - [%s] %s (%.0f м)\n", step.ManeuverType, step.Instruction, step.Distance)
+		}
+	}
+	fmt.Printf("  Разом: %.2f км (%.0f м), %.0f хв (%.0f сек)\n",
+		route.Distance/1000, route.Distance, route.Duration/60, route.Duration)
+}
+
+type geoJSONLineStringFeature struct {
+	Type     string              `json:"type"`
+	Geometry geoJSONLineGeometry `json:"geometry"`
+}
+
+type geoJSONLineGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+// writeRouteGeoJSON зберігає геометрію маршруту як GeoJSON LineString,
+// придатний для подальшої візуалізації на карті.
+func writeRouteGeoJSON(path string, route *RouteData) error {
+	feature := geoJSONLineStringFeature{
+		Type: "Feature",
+		Geometry: geoJSONLineGeometry{
+			Type:        "LineString",
+			Coordinates: route.Geometry.Coordinates,
+		},
+	}
+
+	data, err := json.MarshalIndent(feature, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}